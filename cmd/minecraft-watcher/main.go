@@ -2,51 +2,55 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
-	"sync/atomic"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/elhedran/minecraft-watcher/internal/fingerprint"
+	"github.com/elhedran/minecraft-watcher/internal/metrics"
+	"github.com/elhedran/minecraft-watcher/internal/mgmtclient"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-type Config struct {
-	Host                string
-	Port                string
-	Secret              string
-	TLSEnabled          bool
-	TestMode            bool
-	IdleTimeoutMinutes  int
-	MinUptimeMinutes    int
-	PollIntervalSeconds int
-}
-
-type JSONRPCRequest struct {
-	JSONRPC string      `json:"jsonrpc"`
-	Method  string      `json:"method"`
-	ID      int         `json:"id"`
-	Params  interface{} `json:"params,omitempty"`
-}
+// rpcTimeout bounds how long a single sendJSONRPC call waits for its
+// response before giving up.
+const rpcTimeout = 10 * time.Second
 
-type JSONRPCResponse struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      int             `json:"id"`
-	Result  json.RawMessage `json:"result,omitempty"`
-	Error   *JSONRPCError   `json:"error,omitempty"`
-}
+// reconcileEvery controls how many poll ticks pass between full
+// minecraft:players reconciliations. Between reconciliations, player counts
+// come from joined/left notifications dispatched by the management client.
+const reconcileEvery = 5
 
-type JSONRPCError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    string `json:"data,omitempty"`
+type Config struct {
+	Host                       string
+	Port                       string
+	GamePort                   int
+	Secret                     string
+	TLSEnabled                 bool
+	MgmtCAFile                 string
+	MgmtServerName             string
+	MgmtTLSPin                 string
+	MgmtTLSInsecure            bool
+	TestMode                   bool
+	IdleTimeoutMinutes         int
+	MinUptimeMinutes           int
+	PollIntervalSeconds        int
+	FingerprintIntervalMinutes int
+	LogFormat                  string
+	LogLevel                   string
+	MetricsAddr                string
+	ShutdownStrategy           string
+	ShutdownExecMethod         string
+	ShutdownExecParams         string
 }
 
 type PlayersResult struct {
@@ -58,16 +62,68 @@ type Player struct {
 	Name string `json:"name"`
 }
 
+// playerEvent is the payload of a notification:players/joined or
+// notification:players/left event.
+type playerEvent struct {
+	Player Player `json:"player"`
+}
+
+// Watcher owns the management client for a Minecraft server and the logger
+// used to report on it. Consumers embedding minecraft-watcher can supply
+// their own *zap.Logger instead of relying on newLogger's env-driven
+// defaults.
+//
+// The management client dispatches player join/leave notifications to
+// handlePlayerJoined/handlePlayerLeft as they arrive, so the in-memory
+// player set (and lastPlayerTime) stay current between polls.
+type Watcher struct {
+	Cfg      *Config
+	Logger   *zap.Logger
+	Strategy ShutdownStrategy
+	client   *mgmtclient.Client
+
+	playersMu sync.Mutex
+	players   map[string]Player
+
+	stateMu        sync.Mutex
+	startTime      time.Time
+	lastPlayerTime time.Time
+}
+
+// newWatcher constructs a Watcher ready to connect, with its player set
+// initialized so notification handlers and reconcilePlayers can write to it.
+func newWatcher(cfg *Config, logger *zap.Logger, strategy ShutdownStrategy, client *mgmtclient.Client) *Watcher {
+	return &Watcher{
+		Cfg:      cfg,
+		Logger:   logger,
+		Strategy: strategy,
+		client:   client,
+		players:  make(map[string]Player),
+	}
+}
+
 func loadConfig() (*Config, error) {
 	cfg := &Config{
-		Host:                getEnv("MINECRAFT_MGMT_HOST", "localhost"),
-		Port:                getEnv("MINECRAFT_MGMT_PORT", "25566"),
-		Secret:              os.Getenv("MINECRAFT_MGMT_SECRET"),
-		TLSEnabled:          getEnvBool("MINECRAFT_MGMT_TLS_ENABLED", true),
-		TestMode:            getEnvBool("TEST_MODE", false),
-		IdleTimeoutMinutes:  getEnvInt("IDLE_TIMEOUT_MINUTES", 10),
-		MinUptimeMinutes:    getEnvInt("MIN_UPTIME_MINUTES", 30),
-		PollIntervalSeconds: getEnvInt("POLL_INTERVAL_SECONDS", 30),
+		Host:                       getEnv("MINECRAFT_MGMT_HOST", "localhost"),
+		Port:                       getEnv("MINECRAFT_MGMT_PORT", "25566"),
+		GamePort:                   getEnvInt("MINECRAFT_GAME_PORT", 25565),
+		Secret:                     os.Getenv("MINECRAFT_MGMT_SECRET"),
+		TLSEnabled:                 getEnvBool("MINECRAFT_MGMT_TLS_ENABLED", true),
+		MgmtCAFile:                 os.Getenv("MINECRAFT_MGMT_CA_FILE"),
+		MgmtServerName:             os.Getenv("MINECRAFT_MGMT_SERVER_NAME"),
+		MgmtTLSPin:                 os.Getenv("MINECRAFT_MGMT_TLS_PIN"),
+		MgmtTLSInsecure:            getEnvBool("MINECRAFT_MGMT_TLS_INSECURE", false),
+		TestMode:                   getEnvBool("TEST_MODE", false),
+		IdleTimeoutMinutes:         getEnvInt("IDLE_TIMEOUT_MINUTES", 10),
+		MinUptimeMinutes:           getEnvInt("MIN_UPTIME_MINUTES", 30),
+		PollIntervalSeconds:        getEnvInt("POLL_INTERVAL_SECONDS", 30),
+		FingerprintIntervalMinutes: getEnvInt("FINGERPRINT_INTERVAL_MINUTES", 60),
+		LogFormat:                  getEnv("LOG_FORMAT", "json"),
+		LogLevel:                   getEnv("LOG_LEVEL", "info"),
+		MetricsAddr:                getEnv("METRICS_ADDR", ":9101"),
+		ShutdownStrategy:           getEnv("SHUTDOWN_STRATEGY", "immediate"),
+		ShutdownExecMethod:         os.Getenv("SHUTDOWN_EXEC_METHOD"),
+		ShutdownExecParams:         os.Getenv("SHUTDOWN_EXEC_PARAMS"),
 	}
 
 	if cfg.Secret == "" {
@@ -102,20 +158,59 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
-func main() {
-	log.Println("minecraft-watcher starting...")
+// newLogger builds a *zap.Logger from cfg.LogFormat ("json" or "console")
+// and cfg.LogLevel (any level zapcore.ParseLevel accepts). It falls back to
+// the production JSON encoder and info level on invalid input rather than
+// failing startup over a logging misconfiguration.
+func newLogger(cfg *Config) (*zap.Logger, error) {
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		level = zap.InfoLevel
+	}
 
+	var zapCfg zap.Config
+	if cfg.LogFormat == "console" {
+		zapCfg = zap.NewDevelopmentConfig()
+	} else {
+		zapCfg = zap.NewProductionConfig()
+	}
+	zapCfg.Level = zap.NewAtomicLevelAt(level)
+
+	return zapCfg.Build()
+}
+
+func main() {
 	cfg, err := loadConfig()
 	if err != nil {
-		log.Fatalf("Configuration error: %v", err)
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger, err := newLogger(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
 	}
+	defer logger.Sync()
+
+	logger.Info("minecraft-watcher starting")
 
 	if cfg.TestMode {
-		log.Println("*** RUNNING IN TEST MODE - will not actually shut down server ***")
+		logger.Warn("running in test mode - will not actually shut down server")
+	}
+
+	if cfg.TLSEnabled && cfg.MgmtTLSInsecure {
+		logger.Warn("MINECRAFT_MGMT_TLS_INSECURE is set - TLS certificate verification is disabled, do not use this in production")
 	}
 
-	log.Printf("Configuration: host=%s, port=%s, tls=%v, idle_timeout=%dm, min_uptime=%dm, poll_interval=%ds",
-		cfg.Host, cfg.Port, cfg.TLSEnabled, cfg.IdleTimeoutMinutes, cfg.MinUptimeMinutes, cfg.PollIntervalSeconds)
+	logger.Info("configuration loaded",
+		zap.String("host", cfg.Host),
+		zap.String("port", cfg.Port),
+		zap.Bool("tls", cfg.TLSEnabled),
+		zap.Int("idle_timeout_min", cfg.IdleTimeoutMinutes),
+		zap.Int("min_uptime_min", cfg.MinUptimeMinutes),
+		zap.Int("poll_interval_sec", cfg.PollIntervalSeconds),
+	)
 
 	// Setup signal handling for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -126,51 +221,53 @@ func main() {
 
 	go func() {
 		sig := <-sigChan
-		log.Printf("Received signal %v, shutting down gracefully...", sig)
+		logger.Info("received signal, shutting down gracefully", zap.String("signal", sig.String()))
 		cancel()
 	}()
 
-	conn := connectWithRetry(cfg)
-	defer func() {
-		log.Println("Closing connection to Minecraft server...")
-		conn.Close()
-		log.Println("Shutdown complete")
-	}()
+	if cfg.MetricsAddr != "" {
+		logger.Info("starting metrics server", zap.String("addr", cfg.MetricsAddr))
+		metrics.Serve(ctx, cfg.MetricsAddr)
+	}
 
-	log.Println("minecraft-watcher ready - connected to server")
+	strategy, err := newShutdownStrategy(cfg)
+	if err != nil {
+		logger.Fatal("invalid shutdown strategy configuration", zap.Error(err))
+	}
 
-	monitorPlayers(ctx, conn, cfg)
-}
+	client, err := newMgmtClient(cfg, logger)
+	if err != nil {
+		logger.Fatal("invalid management client configuration", zap.Error(err))
+	}
 
-func connectWithRetry(cfg *Config) *websocket.Conn {
-	backoff := 1 * time.Second
-	maxBackoff := 30 * time.Second
-	attempt := 1
+	w := newWatcher(cfg, logger, strategy, client)
+	w.registerNotificationHandlers()
 
-	for {
-		log.Printf("Attempting connection to Minecraft server (attempt %d)...", attempt)
+	logger.Info("connecting to Minecraft server management API")
+	if err := client.Start(ctx); err != nil {
+		logger.Fatal("failed to connect to Minecraft server", zap.Error(err))
+	}
+	go w.watchReconnects(ctx)
 
-		conn, err := connectToServer(cfg)
-		if err != nil {
-			log.Printf("Connection failed: %v", err)
-			log.Printf("Retrying in %v...", backoff)
-			time.Sleep(backoff)
-
-			// Exponential backoff
-			backoff *= 2
-			if backoff > maxBackoff {
-				backoff = maxBackoff
-			}
-			attempt++
-			continue
-		}
+	if err := w.subscribeToPlayerEvents(ctx); err != nil {
+		logger.Warn("failed to subscribe to player notifications, falling back to polling only", zap.Error(err))
+	}
 
-		log.Println("Successfully connected to Minecraft server")
-		return conn
+	if _, err := w.Fingerprint(ctx); err != nil {
+		logger.Warn("failed to fingerprint server", zap.Error(err))
 	}
+	go w.periodicFingerprint(ctx)
+
+	logger.Info("minecraft-watcher ready - connected to server")
+
+	w.monitorPlayers(ctx)
+
+	logger.Info("shutdown complete")
 }
 
-func connectToServer(cfg *Config) (*websocket.Conn, error) {
+// newMgmtClient builds the reconnecting management client from cfg. The
+// connection itself isn't established until Start is called.
+func newMgmtClient(cfg *Config, logger *zap.Logger) (*mgmtclient.Client, error) {
 	scheme := "ws"
 	if cfg.TLSEnabled {
 		scheme = "wss"
@@ -185,141 +282,370 @@ func connectToServer(cfg *Config) (*websocket.Conn, error) {
 	header := http.Header{}
 	header.Set("Authorization", fmt.Sprintf("Bearer %s", cfg.Secret))
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
+	opts := mgmtclient.Options{
+		URL:    u.String(),
+		Header: header,
+		Logger: logger,
 	}
 
-	// Skip TLS verification for now (should be configurable in production)
 	if cfg.TLSEnabled {
-		dialer.TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
+		tlsCfg, err := buildTLSConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build TLS config: %w", err)
 		}
+		opts.TLSConfig = tlsCfg
 	}
 
-	conn, _, err := dialer.Dial(u.String(), header)
-	if err != nil {
-		return nil, err
+	return mgmtclient.New(opts), nil
+}
+
+// watchReconnects logs and counts every reconnect signaled by the
+// management client (the first connection from Start doesn't come through
+// here), and re-subscribes to player notifications since a fresh connection
+// doesn't remember the previous one's subscription.
+func (w *Watcher) watchReconnects(ctx context.Context) {
+	first := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.client.Connected():
+			if first {
+				first = false
+				continue
+			}
+			metrics.ReconnectsTotal.Inc()
+			w.Logger.Info("management connection re-established")
+			if err := w.subscribeToPlayerEvents(ctx); err != nil {
+				w.Logger.Warn("failed to re-subscribe to player notifications after reconnect", zap.Error(err))
+			}
+		}
 	}
+}
 
-	return conn, nil
+// registerNotificationHandlers wires up the handlers for player join/leave
+// notifications. This only needs to happen once: mgmtclient.OnNotification
+// registrations aren't reset by a reconnect.
+func (w *Watcher) registerNotificationHandlers() {
+	w.client.OnNotification("notification:players/joined", func(params json.RawMessage) {
+		w.handlePlayerJoined(params)
+	})
+	w.client.OnNotification("notification:players/left", func(params json.RawMessage) {
+		w.handlePlayerLeft(params)
+	})
 }
 
-var requestID int64
+// subscribeToPlayerEvents asks the server to start emitting join/leave
+// notifications. If it doesn't support notifications:subscribe, the caller
+// falls back to polling.
+func (w *Watcher) subscribeToPlayerEvents(ctx context.Context) error {
+	if err := w.sendJSONRPC(ctx, "notifications:subscribe", map[string]interface{}{
+		"events": []string{"players/joined", "players/left"},
+	}, nil); err != nil {
+		return err
+	}
 
-func sendJSONRPC(conn *websocket.Conn, method string, params interface{}) (*JSONRPCResponse, error) {
-	id := int(atomic.AddInt64(&requestID, 1))
+	w.Logger.Info("subscribed to player join/leave notifications")
+	return nil
+}
 
-	req := JSONRPCRequest{
-		JSONRPC: "2.0",
-		Method:  method,
-		ID:      id,
-		Params:  params,
+func (w *Watcher) handlePlayerJoined(params json.RawMessage) {
+	var evt playerEvent
+	if err := json.Unmarshal(params, &evt); err != nil {
+		w.Logger.Warn("failed to parse players/joined notification", zap.Error(err))
+		return
 	}
+	w.Logger.Info("player joined", zap.String("player_name", evt.Player.Name))
+	w.addPlayer(evt.Player)
+}
 
-	if err := conn.WriteJSON(req); err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+func (w *Watcher) handlePlayerLeft(params json.RawMessage) {
+	var evt playerEvent
+	if err := json.Unmarshal(params, &evt); err != nil {
+		w.Logger.Warn("failed to parse players/left notification", zap.Error(err))
+		return
 	}
+	w.Logger.Info("player left", zap.String("player_name", evt.Player.Name))
+	w.removePlayer(evt.Player.ID)
+}
+
+func (w *Watcher) addPlayer(p Player) {
+	w.playersMu.Lock()
+	w.players[p.ID] = p
+	w.playersMu.Unlock()
+	w.touchLastPlayerTime()
+}
+
+func (w *Watcher) removePlayer(id string) {
+	w.playersMu.Lock()
+	delete(w.players, id)
+	w.playersMu.Unlock()
+}
 
-	var resp JSONRPCResponse
-	if err := conn.ReadJSON(&resp); err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+func (w *Watcher) replacePlayers(players []Player) {
+	w.playersMu.Lock()
+	w.players = make(map[string]Player, len(players))
+	for _, p := range players {
+		w.players[p.ID] = p
+	}
+	w.playersMu.Unlock()
+	if len(players) > 0 {
+		w.touchLastPlayerTime()
 	}
+}
+
+func (w *Watcher) currentPlayers() []Player {
+	w.playersMu.Lock()
+	defer w.playersMu.Unlock()
+
+	players := make([]Player, 0, len(w.players))
+	for _, p := range w.players {
+		players = append(players, p)
+	}
+	return players
+}
+
+func (w *Watcher) touchLastPlayerTime() {
+	w.stateMu.Lock()
+	w.lastPlayerTime = time.Now()
+	w.stateMu.Unlock()
+}
+
+func (w *Watcher) lastSeenPlayer() time.Time {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+	return w.lastPlayerTime
+}
 
-	if resp.Error != nil {
-		return nil, fmt.Errorf("JSON-RPC error %d: %s (data: %s)",
-			resp.Error.Code, resp.Error.Message, resp.Error.Data)
+// sendJSONRPC calls method via the management client, bounded by rpcTimeout,
+// recording the usual RPC metrics. result may be nil if the caller doesn't
+// need the response decoded.
+func (w *Watcher) sendJSONRPC(ctx context.Context, method string, params interface{}, result interface{}) error {
+	callCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+
+	w.Logger.Debug("sending JSON-RPC request", zap.String("method", method))
+
+	start := time.Now()
+	err := w.client.CallContext(callCtx, method, params, result)
+	metrics.RPCLatencySeconds.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+	status := "ok"
+	if err != nil {
+		status = "error"
 	}
+	metrics.RPCRequestsTotal.WithLabelValues(method, status).Inc()
 
-	return &resp, nil
+	return err
 }
 
-func getPlayers(conn *websocket.Conn) ([]Player, error) {
-	resp, err := sendJSONRPC(conn, "minecraft:players", nil)
+// Fingerprint performs a Server List Ping against the game port and
+// classifies the server software running there. It also records the result
+// as the mcw_server_info metric so operators can see what flavor is
+// running alongside the rest of the watcher's metrics.
+func (w *Watcher) Fingerprint(ctx context.Context) (*fingerprint.Result, error) {
+	result, err := fingerprint.Probe(ctx, w.Cfg.Host, w.Cfg.GamePort)
 	if err != nil {
 		return nil, err
 	}
 
-	var players []Player
-	if err := json.Unmarshal(resp.Result, &players); err != nil {
-		return nil, fmt.Errorf("failed to parse players result: %w", err)
+	w.Logger.Info("fingerprinted server",
+		zap.String("software", string(result.Software)),
+		zap.String("version", result.VersionName),
+		zap.Int("players_online", result.PlayersOnline),
+	)
+	metrics.ServerInfo.Reset()
+	metrics.ServerInfo.WithLabelValues(string(result.Software), result.VersionName).Set(1)
+
+	return result, nil
+}
+
+// periodicFingerprint re-probes the server every FingerprintIntervalMinutes
+// so mcw_server_info stays current even on the healthy path, where the only
+// other callers of Fingerprint are the single probe at startup and
+// reconcilePlayers's SLP fallback, which never runs.
+func (w *Watcher) periodicFingerprint(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(w.Cfg.FingerprintIntervalMinutes) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.Fingerprint(ctx); err != nil {
+				w.Logger.Warn("failed to fingerprint server", zap.Error(err))
+			}
+		}
 	}
+}
 
+func (w *Watcher) getPlayers(ctx context.Context) ([]Player, error) {
+	var players []Player
+	if err := w.sendJSONRPC(ctx, "minecraft:players", nil, &players); err != nil {
+		return nil, err
+	}
 	return players, nil
 }
 
-func shutdownServer(conn *websocket.Conn, testMode bool) error {
-	if testMode {
-		log.Println("TEST MODE: Would execute server shutdown now")
+func (w *Watcher) shutdownServer(ctx context.Context) error {
+	if w.Cfg.TestMode {
+		w.Logger.Info("TEST MODE: would execute server shutdown now", zap.String("strategy", w.Strategy.Name()))
 		return nil
 	}
 
-	log.Println("Sending shutdown command to server...")
-	_, err := sendJSONRPC(conn, "minecraft:server/stop", nil)
-	if err != nil {
-		return fmt.Errorf("failed to shutdown server: %w", err)
+	w.Logger.Info("executing shutdown strategy", zap.String("strategy", w.Strategy.Name()))
+	if err := w.Strategy.Shutdown(ctx, w); err != nil {
+		return fmt.Errorf("shutdown strategy %s failed: %w", w.Strategy.Name(), err)
 	}
 
-	log.Println("Server shutdown command sent successfully")
+	w.Logger.Info("shutdown strategy completed successfully", zap.String("strategy", w.Strategy.Name()))
 	return nil
 }
 
-func monitorPlayers(ctx context.Context, conn *websocket.Conn, cfg *Config) {
-	startTime := time.Now()
-	lastPlayerTime := time.Now()
-	ticker := time.NewTicker(time.Duration(cfg.PollIntervalSeconds) * time.Second)
+// monitorPlayers evaluates shutdown conditions on each tick using the
+// in-memory player set, which notification handlers keep up to date in real
+// time. It reconciles once up front (so a server that already has players
+// connected at startup isn't reported as idle) and then every reconcileEvery
+// ticks thereafter, both as a heartbeat (to notice a dead connection) and to
+// reconcile any notification the watcher might have missed. Because the
+// management client reconnects on its own and queues requests made while it
+// is disconnected, a failed poll no longer means the watcher has to give up
+// and exit; a request in flight when the connection drops fails rather than
+// being retried.
+func (w *Watcher) monitorPlayers(ctx context.Context) {
+	w.stateMu.Lock()
+	w.startTime = time.Now()
+	w.lastPlayerTime = time.Now()
+	w.stateMu.Unlock()
+
+	ticker := time.NewTicker(time.Duration(w.Cfg.PollIntervalSeconds) * time.Second)
 	defer ticker.Stop()
 
-	log.Println("Starting player monitoring loop...")
+	w.Logger.Info("starting player monitoring loop")
 
+	// Reconcile once up front so a server that already has players online
+	// when the watcher starts is reflected immediately,
+	// rather than reporting 0 players for up to reconcileEvery ticks.
+	if err := w.reconcilePlayers(ctx); err != nil {
+		w.Logger.Warn("error reconciling player list", zap.Error(err))
+	}
+
+	tick := 0
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Monitoring stopped")
+			w.Logger.Info("monitoring stopped")
 			return
 		case <-ticker.C:
-			players, err := getPlayers(conn)
-			if err != nil {
-				log.Printf("Error getting players: %v", err)
-				continue
+			tick++
+			if tick%reconcileEvery == 0 {
+				if err := w.reconcilePlayers(ctx); err != nil {
+					w.Logger.Warn("error reconciling player list", zap.Error(err))
+				}
 			}
 
+			players := w.currentPlayers()
 			playerCount := len(players)
+			metrics.PlayersOnline.Set(float64(playerCount))
+
+			if playerCount > 0 {
+				w.touchLastPlayerTime()
+			}
+			lastPlayerTime := w.lastSeenPlayer()
 			if playerCount > 0 {
-				lastPlayerTime = time.Now()
 				playerNames := make([]string, len(players))
 				for i, p := range players {
 					playerNames[i] = p.Name
 				}
-				log.Printf("Players online (%d): %v", playerCount, playerNames)
+				w.Logger.Info("players online",
+					zap.Int("player_count", playerCount),
+					zap.Strings("player_names", playerNames),
+				)
 			} else {
-				timeSinceLastPlayer := time.Since(lastPlayerTime)
-				log.Printf("No players online (idle for %v)", timeSinceLastPlayer.Round(time.Second))
+				w.Logger.Info("no players online",
+					zap.Int("player_count", 0),
+					zap.Int("idle_min", int(time.Since(lastPlayerTime).Minutes())),
+				)
 			}
 
 			// Check shutdown conditions
-			uptime := time.Since(startTime)
+			uptime := time.Since(w.startTime)
 			timeSinceLastPlayer := time.Since(lastPlayerTime)
 
 			uptimeMinutes := int(uptime.Minutes())
 			idleMinutes := int(timeSinceLastPlayer.Minutes())
 
-			log.Printf("Status: uptime=%dm, idle=%dm (thresholds: min_uptime=%dm, idle_timeout=%dm)",
-				uptimeMinutes, idleMinutes, cfg.MinUptimeMinutes, cfg.IdleTimeoutMinutes)
-
-			if uptimeMinutes >= cfg.MinUptimeMinutes && idleMinutes >= cfg.IdleTimeoutMinutes {
-				log.Printf("Shutdown conditions met: uptime=%dm >= %dm AND idle=%dm >= %dm",
-					uptimeMinutes, cfg.MinUptimeMinutes, idleMinutes, cfg.IdleTimeoutMinutes)
-
-				if err := shutdownServer(conn, cfg.TestMode); err != nil {
-					log.Printf("Error shutting down server: %v", err)
+			metrics.UptimeSeconds.Set(uptime.Seconds())
+			metrics.IdleSeconds.Set(timeSinceLastPlayer.Seconds())
+
+			w.Logger.Debug("status",
+				zap.Int("uptime_min", uptimeMinutes),
+				zap.Int("idle_min", idleMinutes),
+				zap.Int("min_uptime_min", w.Cfg.MinUptimeMinutes),
+				zap.Int("idle_timeout_min", w.Cfg.IdleTimeoutMinutes),
+			)
+
+			if uptimeMinutes >= w.Cfg.MinUptimeMinutes && idleMinutes >= w.Cfg.IdleTimeoutMinutes {
+				w.Logger.Info("shutdown conditions met",
+					zap.Int("uptime_min", uptimeMinutes),
+					zap.Int("idle_min", idleMinutes),
+				)
+
+				metrics.ShutdownTriggeredTotal.Inc()
+				if err := w.shutdownServer(ctx); err != nil {
+					if errors.Is(err, ErrShutdownAborted) {
+						w.Logger.Info("shutdown aborted, resuming monitoring")
+					} else {
+						w.Logger.Error("error shutting down server", zap.Error(err))
+					}
 					continue
 				}
 
-				if !cfg.TestMode {
-					log.Println("Server shutdown initiated. Exiting.")
+				if !w.Cfg.TestMode && w.Strategy.Terminates() {
+					w.Logger.Info("server shutdown initiated, exiting")
 					os.Exit(0)
 				}
+
+				// Non-terminating strategies (e.g. exec_command) don't stop
+				// the server, so reset the idle clock and keep monitoring
+				// rather than re-triggering the strategy every tick.
+				w.stateMu.Lock()
+				w.lastPlayerTime = time.Now()
+				w.stateMu.Unlock()
 			}
 		}
 	}
 }
+
+// reconcilePlayers polls minecraft:players directly and replaces the
+// in-memory player set with the authoritative result. This is the fallback
+// path when notifications are unavailable or a join/leave event was missed.
+// If the management websocket itself is unavailable, it falls back further
+// to an SLP probe of the game port as a second signal source.
+func (w *Watcher) reconcilePlayers(ctx context.Context) error {
+	players, err := w.getPlayers(ctx)
+	if err == nil {
+		w.replacePlayers(players)
+		return nil
+	}
+
+	w.Logger.Warn("minecraft:players unavailable, falling back to SLP probe", zap.Error(err))
+
+	result, fErr := w.Fingerprint(ctx)
+	if fErr != nil {
+		return fmt.Errorf("rpc failed (%v) and SLP fallback failed: %w", err, fErr)
+	}
+
+	sample := make([]Player, len(result.PlayerSample))
+	for i, p := range result.PlayerSample {
+		sample[i] = Player{ID: p.ID, Name: p.Name}
+	}
+	w.replacePlayers(sample)
+	if len(sample) == 0 && result.PlayersOnline > 0 {
+		// SLP player sample is often empty even when players are online;
+		// use the online count alone to keep the idle timer from resetting.
+		w.touchLastPlayerTime()
+	}
+	return nil
+}
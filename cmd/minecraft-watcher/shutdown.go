@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrShutdownAborted is returned by a ShutdownStrategy when it backs out of
+// a shutdown in progress, e.g. because a player joined mid-countdown. It is
+// not a failure: the watcher simply resumes normal monitoring.
+var ErrShutdownAborted = errors.New("shutdown aborted")
+
+// ShutdownStrategy decides how the watcher actually takes the server down
+// once idle/uptime conditions are met. Selection is via SHUTDOWN_STRATEGY.
+type ShutdownStrategy interface {
+	Name() string
+	Shutdown(ctx context.Context, w *Watcher) error
+
+	// Terminates reports whether a successful Shutdown is expected to stop
+	// the server process. The watcher exits after such a strategy succeeds;
+	// strategies that don't terminate the server (e.g. ExecCommand running
+	// a non-stopping command) leave the watcher running so it keeps
+	// monitoring a server that's still up.
+	Terminates() bool
+}
+
+// newShutdownStrategy builds the ShutdownStrategy named by cfg.ShutdownStrategy.
+func newShutdownStrategy(cfg *Config) (ShutdownStrategy, error) {
+	switch cfg.ShutdownStrategy {
+	case "", "immediate":
+		return ImmediateStop{}, nil
+	case "graceful":
+		return GracefulCountdown{}, nil
+	case "save_then_stop":
+		return SaveThenStop{}, nil
+	case "exec_command":
+		if cfg.ShutdownExecMethod == "" {
+			return nil, fmt.Errorf("SHUTDOWN_EXEC_METHOD is required for the exec_command shutdown strategy")
+		}
+		var params interface{}
+		if cfg.ShutdownExecParams != "" {
+			if err := json.Unmarshal([]byte(cfg.ShutdownExecParams), &params); err != nil {
+				return nil, fmt.Errorf("invalid SHUTDOWN_EXEC_PARAMS: %w", err)
+			}
+		}
+		return ExecCommand{Method: cfg.ShutdownExecMethod, Params: params}, nil
+	default:
+		return nil, fmt.Errorf("unknown SHUTDOWN_STRATEGY %q", cfg.ShutdownStrategy)
+	}
+}
+
+// ImmediateStop is the original behavior: call minecraft:server/stop with
+// no warning.
+type ImmediateStop struct{}
+
+func (ImmediateStop) Name() string { return "immediate" }
+
+func (ImmediateStop) Terminates() bool { return true }
+
+func (ImmediateStop) Shutdown(ctx context.Context, w *Watcher) error {
+	return w.sendJSONRPC(ctx, "minecraft:server/stop", nil, nil)
+}
+
+// gracefulWarnings are the points at which GracefulCountdown broadcasts a
+// warning, expressed as time remaining before stop.
+var gracefulWarnings = []time.Duration{5 * time.Minute, 1 * time.Minute, 30 * time.Second, 10 * time.Second}
+
+// GracefulCountdown broadcasts warnings via minecraft:server/say at each of
+// gracefulWarnings before calling minecraft:server/stop, and aborts with
+// ErrShutdownAborted if a player joins at any point during the countdown.
+type GracefulCountdown struct{}
+
+func (GracefulCountdown) Name() string { return "graceful" }
+
+func (GracefulCountdown) Terminates() bool { return true }
+
+func (GracefulCountdown) Shutdown(ctx context.Context, w *Watcher) error {
+	for i, remaining := range gracefulWarnings {
+		msg := fmt.Sprintf("Server shutting down in %s", remaining.Round(time.Second))
+		if err := w.sendJSONRPC(ctx, "minecraft:server/say", map[string]interface{}{"message": msg}, nil); err != nil {
+			w.Logger.Warn("failed to broadcast shutdown warning", zap.Error(err))
+		}
+
+		wait := remaining
+		if i+1 < len(gracefulWarnings) {
+			wait = remaining - gracefulWarnings[i+1]
+		}
+
+		if w.waitUnlessPlayerJoins(ctx, wait) {
+			return ErrShutdownAborted
+		}
+	}
+
+	return w.sendJSONRPC(ctx, "minecraft:server/stop", nil, nil)
+}
+
+// SaveThenStop issues minecraft:server/save and waits for its response
+// before calling minecraft:server/stop.
+type SaveThenStop struct{}
+
+func (SaveThenStop) Name() string { return "save_then_stop" }
+
+func (SaveThenStop) Terminates() bool { return true }
+
+func (SaveThenStop) Shutdown(ctx context.Context, w *Watcher) error {
+	w.Logger.Info("saving world before shutdown")
+	if err := w.sendJSONRPC(ctx, "minecraft:server/save", nil, nil); err != nil {
+		return fmt.Errorf("save failed: %w", err)
+	}
+
+	return w.sendJSONRPC(ctx, "minecraft:server/stop", nil, nil)
+}
+
+// ExecCommand runs an arbitrary configured RPC method instead of stopping
+// the server outright, e.g. a plugin command or a /kick.
+type ExecCommand struct {
+	Method string
+	Params interface{}
+}
+
+func (ExecCommand) Name() string { return "exec_command" }
+
+// Terminates is false: exec_command runs an arbitrary configured method
+// (a /kick, a plugin command, ...) which isn't guaranteed to stop the
+// server, so the watcher keeps monitoring afterwards rather than exiting.
+func (ExecCommand) Terminates() bool { return false }
+
+func (s ExecCommand) Shutdown(ctx context.Context, w *Watcher) error {
+	return w.sendJSONRPC(ctx, s.Method, s.Params, nil)
+}
+
+// waitUnlessPlayerJoins blocks for d, actively reconciling the player set
+// once a second via reconcilePlayers (a direct minecraft:players poll,
+// falling back to an SLP probe), and returns true early if a player joins
+// or ctx is cancelled. monitorPlayers is blocked on Shutdown for the whole
+// countdown, so notifications alone can't be relied on here -- in
+// polling-only mode (no active notifications:subscribe) nothing else would
+// refresh the player set during the wait.
+func (w *Watcher) waitUnlessPlayerJoins(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	poll := time.NewTicker(time.Second)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-timer.C:
+			return false
+		case <-poll.C:
+			if err := w.reconcilePlayers(ctx); err != nil {
+				w.Logger.Warn("error reconciling player list during shutdown countdown", zap.Error(err))
+			}
+			if len(w.currentPlayers()) > 0 {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elhedran/minecraft-watcher/internal/mgmtclient"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// callLog records, under a mutex, the RPC methods a test server observed.
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) record(method string) {
+	l.mu.Lock()
+	l.calls = append(l.calls, method)
+	l.mu.Unlock()
+}
+
+func (l *callLog) snapshot() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.calls...)
+}
+
+// newTestWatcher starts a websocket server that echoes back a {"result":
+// true} response to every request and records the methods it receives, then
+// wires up a Watcher against it exactly as newWatcher would in production.
+func newTestWatcher(t *testing.T) (*Watcher, *callLog) {
+	t.Helper()
+
+	log := &callLog{}
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var req mgmtclient.Request
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			log.record(req.Method)
+
+			resp := mgmtclient.Response{JSONRPC: "2.0", ID: req.ID, Result: []byte("true")}
+			if err := conn.WriteJSON(resp); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := mgmtclient.New(mgmtclient.Options{URL: "ws" + strings.TrimPrefix(srv.URL, "http")})
+	// ctx governs the client's whole connection lifetime (manageLoop keeps
+	// running until it's done), not just the initial dial, so it must
+	// outlive this function -- cancel it when the test ends instead.
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	cfg := &Config{}
+	w := newWatcher(cfg, zap.NewNop(), nil, client)
+	return w, log
+}
+
+func TestStrategyTerminates(t *testing.T) {
+	cases := []struct {
+		strategy ShutdownStrategy
+		want     bool
+	}{
+		{ImmediateStop{}, true},
+		{GracefulCountdown{}, true},
+		{SaveThenStop{}, true},
+		{ExecCommand{Method: "some:plugin/command"}, false},
+	}
+
+	for _, c := range cases {
+		if got := c.strategy.Terminates(); got != c.want {
+			t.Errorf("%s.Terminates() = %v, want %v", c.strategy.Name(), got, c.want)
+		}
+	}
+}
+
+func TestGracefulCountdownWarningOffsets(t *testing.T) {
+	want := []time.Duration{5 * time.Minute, 1 * time.Minute, 30 * time.Second, 10 * time.Second}
+	if len(gracefulWarnings) != len(want) {
+		t.Fatalf("gracefulWarnings = %v, want %v", gracefulWarnings, want)
+	}
+	for i, d := range want {
+		if gracefulWarnings[i] != d {
+			t.Errorf("gracefulWarnings[%d] = %v, want %v", i, gracefulWarnings[i], d)
+		}
+	}
+}
+
+func TestGracefulCountdownAbortsOnPlayerJoin(t *testing.T) {
+	w, calls := newTestWatcher(t)
+
+	// Shrink the countdown so the test doesn't wait minutes: the last two
+	// warnings are 20s apart, well over waitUnlessPlayerJoins' 1s poll.
+	orig := gracefulWarnings
+	gracefulWarnings = []time.Duration{30 * time.Second, 10 * time.Second}
+	t.Cleanup(func() { gracefulWarnings = orig })
+
+	go func() {
+		time.Sleep(1500 * time.Millisecond)
+		w.addPlayer(Player{ID: "1", Name: "Steve"})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := GracefulCountdown{}.Shutdown(ctx, w)
+	if err != ErrShutdownAborted {
+		t.Fatalf("Shutdown() error = %v, want %v", err, ErrShutdownAborted)
+	}
+
+	got := calls.snapshot()
+	for _, m := range got {
+		if m == "minecraft:server/stop" {
+			t.Errorf("calls = %v, stop should not have been issued after abort", got)
+		}
+	}
+}
+
+func TestGracefulCountdownStopsWhenNoPlayerJoins(t *testing.T) {
+	w, calls := newTestWatcher(t)
+
+	orig := gracefulWarnings
+	gracefulWarnings = []time.Duration{200 * time.Millisecond}
+	t.Cleanup(func() { gracefulWarnings = orig })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := (GracefulCountdown{}).Shutdown(ctx, w); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	got := calls.snapshot()
+	last := got[len(got)-1]
+	if last != "minecraft:server/stop" {
+		t.Errorf("calls = %v, want last call to be minecraft:server/stop", got)
+	}
+}
+
+func TestWaitUnlessPlayerJoinsReturnsEarlyOnJoin(t *testing.T) {
+	w, _ := newTestWatcher(t)
+
+	go func() {
+		time.Sleep(1200 * time.Millisecond)
+		w.addPlayer(Player{ID: "1", Name: "Alex"})
+	}()
+
+	start := time.Now()
+	joined := w.waitUnlessPlayerJoins(context.Background(), 10*time.Second)
+	elapsed := time.Since(start)
+
+	if !joined {
+		t.Fatal("waitUnlessPlayerJoins() = false, want true (player joined)")
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("waitUnlessPlayerJoins took %v, want it to return promptly after the join", elapsed)
+	}
+}
+
+func TestWaitUnlessPlayerJoinsTimesOut(t *testing.T) {
+	w, _ := newTestWatcher(t)
+
+	if joined := w.waitUnlessPlayerJoins(context.Background(), 300*time.Millisecond); joined {
+		t.Error("waitUnlessPlayerJoins() = true, want false (no player joined)")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// buildTLSConfig turns the MINECRAFT_MGMT_TLS_* settings into a tls.Config
+// for the management websocket connection. By default it verifies the
+// server certificate against the system (or cfg.MgmtCAFile) trust store;
+// MgmtTLSInsecure is the only way to disable verification entirely, and
+// callers should warn loudly when it's set.
+func buildTLSConfig(cfg *Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{}
+
+	if cfg.MgmtServerName != "" {
+		tlsCfg.ServerName = cfg.MgmtServerName
+	}
+
+	if cfg.MgmtCAFile != "" {
+		pem, err := os.ReadFile(cfg.MgmtCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MINECRAFT_MGMT_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in MINECRAFT_MGMT_CA_FILE %s", cfg.MgmtCAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.MgmtTLSPin != "" {
+		pinned, err := hex.DecodeString(cfg.MgmtTLSPin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MINECRAFT_MGMT_TLS_PIN: %w", err)
+		}
+		if cfg.MgmtCAFile == "" {
+			// No CA pool to chain-verify against: the pin itself is the
+			// trust anchor, so skip normal chain verification.
+			tlsCfg.InsecureSkipVerify = true
+		}
+		tlsCfg.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return fmt.Errorf("no peer certificate presented")
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse peer certificate: %w", err)
+			}
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			if !bytes.Equal(sum[:], pinned) {
+				return fmt.Errorf("certificate pin mismatch: got %x, want %s", sum, cfg.MgmtTLSPin)
+			}
+			return nil
+		}
+	}
+
+	if cfg.MgmtTLSInsecure {
+		tlsCfg.InsecureSkipVerify = true
+	}
+
+	return tlsCfg, nil
+}
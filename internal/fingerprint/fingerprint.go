@@ -0,0 +1,197 @@
+// Package fingerprint performs a Minecraft Server List Ping (SLP) against a
+// server's game port to identify what it's running without needing the
+// management API. It's used both to label metrics with the server's
+// software/version and as a fallback player-count signal when the
+// management websocket is unavailable.
+package fingerprint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Software is a heuristic classification of the server's distribution,
+// derived from the version string and fields present in the status JSON.
+type Software string
+
+const (
+	Vanilla Software = "vanilla"
+	Paper   Software = "paper"
+	Spigot  Software = "spigot"
+	Fabric  Software = "fabric"
+	Forge   Software = "forge"
+	Purpur  Software = "purpur"
+	Unknown Software = "unknown"
+)
+
+// Player is a single entry from the status response's player sample.
+type Player struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Result is the classified outcome of a Probe.
+type Result struct {
+	VersionName   string
+	Protocol      int
+	MOTD          string
+	Favicon       string
+	PlayersOnline int
+	PlayersMax    int
+	PlayerSample  []Player
+	Software      Software
+}
+
+// statusResponse mirrors the JSON returned by a server in response to an
+// SLP status request. ModInfo/ForgeData are only present on modded servers
+// and are used purely as a classification signal.
+type statusResponse struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int      `json:"max"`
+		Online int      `json:"online"`
+		Sample []Player `json:"sample"`
+	} `json:"players"`
+	Description json.RawMessage `json:"description"`
+	Favicon     string          `json:"favicon"`
+	ModInfo     json.RawMessage `json:"modinfo"`
+	ForgeData   json.RawMessage `json:"forgeData"`
+}
+
+const defaultTimeout = 5 * time.Second
+
+// Probe performs a full SLP handshake/status/ping exchange against
+// host:port and classifies the result. ctx's deadline, if set, bounds the
+// whole exchange; otherwise defaultTimeout is used.
+func Probe(ctx context.Context, host string, port int) (*Result, error) {
+	deadline := time.Now().Add(defaultTimeout)
+	if d, ok := ctx.Deadline(); ok {
+		deadline = d
+	}
+
+	addr := fmt.Sprintf("%s:%d", host, port)
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	if err := writeHandshake(conn, host, port); err != nil {
+		return nil, fmt.Errorf("handshake failed: %w", err)
+	}
+	if err := writePacket(conn, 0x00, nil); err != nil {
+		return nil, fmt.Errorf("status request failed: %w", err)
+	}
+
+	_, payload, err := readPacket(conn)
+	if err != nil {
+		return nil, fmt.Errorf("status response failed: %w", err)
+	}
+
+	jsonLen, n, err := readVarIntFromBytes(payload)
+	if err != nil {
+		return nil, fmt.Errorf("malformed status response: bad json length prefix: %w", err)
+	}
+	if jsonLen < 0 || n+int(jsonLen) > len(payload) {
+		return nil, fmt.Errorf("malformed status response: json length %d out of bounds (payload %d bytes)", jsonLen, len(payload))
+	}
+	var status statusResponse
+	if err := json.Unmarshal(payload[n:n+int(jsonLen)], &status); err != nil {
+		return nil, fmt.Errorf("failed to parse status JSON: %w", err)
+	}
+
+	if err := writePing(conn); err != nil {
+		return nil, fmt.Errorf("ping failed: %w", err)
+	}
+	if _, _, err := readPacket(conn); err != nil {
+		return nil, fmt.Errorf("pong response failed: %w", err)
+	}
+
+	result := &Result{
+		VersionName:   status.Version.Name,
+		Protocol:      status.Version.Protocol,
+		Favicon:       status.Favicon,
+		PlayersOnline: status.Players.Online,
+		PlayersMax:    status.Players.Max,
+		PlayerSample:  status.Players.Sample,
+	}
+	if desc, err := flattenDescription(status.Description); err == nil {
+		result.MOTD = desc
+	}
+	result.Software = classify(status)
+
+	return result, nil
+}
+
+// classify heuristically identifies the server distribution from its
+// version string and the presence of mod-loader-specific status fields.
+func classify(status statusResponse) Software {
+	name := strings.ToLower(status.Version.Name)
+
+	switch {
+	case len(status.ForgeData) > 0, len(status.ModInfo) > 0, strings.Contains(name, "forge"):
+		return Forge
+	case strings.Contains(name, "fabric"):
+		return Fabric
+	case strings.Contains(name, "purpur"):
+		return Purpur
+	case strings.Contains(name, "paper"):
+		return Paper
+	case strings.Contains(name, "spigot"):
+		return Spigot
+	case strings.Contains(name, "vanilla"):
+		return Vanilla
+	default:
+		// Plain "1.20.1"-style version strings with none of the above
+		// markers are most likely vanilla.
+		if isPlainVersionString(name) {
+			return Vanilla
+		}
+		return Unknown
+	}
+}
+
+func isPlainVersionString(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if (r < '0' || r > '9') && r != '.' && r != 'w' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// flattenDescription handles both the legacy plain-string description
+// field and the modern chat-component object form.
+func flattenDescription(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+
+	var plain string
+	if err := json.Unmarshal(raw, &plain); err == nil {
+		return plain, nil
+	}
+
+	var component struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &component); err != nil {
+		return "", err
+	}
+	return component.Text, nil
+}
@@ -0,0 +1,62 @@
+package fingerprint
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   statusResponse
+		expected Software
+	}{
+		{name: "vanilla release", expected: Vanilla},
+		{name: "paper build", expected: Paper},
+		{name: "purpur build", expected: Purpur},
+		{name: "spigot build", expected: Spigot},
+		{name: "fabric loader", expected: Fabric},
+		{name: "unrecognized", expected: Unknown},
+	}
+
+	cases[0].status.Version.Name = "1.20.4"
+	cases[1].status.Version.Name = "Paper 1.20.4"
+	cases[2].status.Version.Name = "Purpur 1.20.4"
+	cases[3].status.Version.Name = "Spigot 1.20.4"
+	cases[4].status.Version.Name = "Fabric 1.20.4"
+	cases[5].status.Version.Name = "CustomLauncher"
+
+	for _, c := range cases {
+		if got := classify(c.status); got != c.expected {
+			t.Errorf("%s: classify() = %q, want %q", c.name, got, c.expected)
+		}
+	}
+}
+
+func TestClassifyForgeFromModInfo(t *testing.T) {
+	status := statusResponse{}
+	status.Version.Name = "1.20.4"
+	status.ModInfo = []byte(`{"type":"FML"}`)
+
+	if got := classify(status); got != Forge {
+		t.Errorf("classify() = %q, want %q", got, Forge)
+	}
+}
+
+func TestVarIntRoundTrip(t *testing.T) {
+	for _, v := range []int32{0, 1, 127, 128, 255, 300, 2097151, -1} {
+		var buf bytes.Buffer
+		writeVarInt(&buf, v)
+
+		got, n, err := readVarIntFromBytes(buf.Bytes())
+		if err != nil {
+			t.Fatalf("readVarIntFromBytes(%d) error: %v", v, err)
+		}
+		if got != v {
+			t.Errorf("round-trip %d: got %d", v, got)
+		}
+		if n != buf.Len() {
+			t.Errorf("round-trip %d: consumed %d bytes, want %d", v, n, buf.Len())
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// handshakeNextStateStatus is the handshake packet's "next state" field
+// value that requests the status (SLP) flow rather than login.
+const handshakeNextStateStatus = 1
+
+func writeHandshake(conn net.Conn, host string, port int) error {
+	var buf bytes.Buffer
+	writeVarInt(&buf, -1) // protocol version: -1 signals "don't care, just want status"
+	writeString(&buf, host)
+	binary.Write(&buf, binary.BigEndian, uint16(port))
+	writeVarInt(&buf, handshakeNextStateStatus)
+	return writePacket(conn, 0x00, buf.Bytes())
+}
+
+func writePing(conn net.Conn) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, int64(0))
+	return writePacket(conn, 0x01, buf.Bytes())
+}
+
+// writePacket frames data with a leading packet-id VarInt and an overall
+// length VarInt, per the Minecraft protocol's packet framing.
+func writePacket(conn net.Conn, packetID int32, data []byte) error {
+	var body bytes.Buffer
+	writeVarInt(&body, packetID)
+	body.Write(data)
+
+	var framed bytes.Buffer
+	writeVarInt(&framed, int32(body.Len()))
+	framed.Write(body.Bytes())
+
+	_, err := conn.Write(framed.Bytes())
+	return err
+}
+
+// maxPacketLength caps the size of a single SLP packet we're willing to
+// buffer. A real status response is at most a few tens of KB; anything
+// larger means we're not talking to a Minecraft server.
+const maxPacketLength = 512 * 1024
+
+// readPacket reads one length-prefixed packet and returns its packet id and
+// remaining payload.
+func readPacket(conn net.Conn) (int32, []byte, error) {
+	length, err := readVarInt(conn)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length < 0 || length > maxPacketLength {
+		return 0, nil, fmt.Errorf("packet length %d out of bounds (max %d)", length, maxPacketLength)
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return 0, nil, err
+	}
+
+	r := bytes.NewReader(buf)
+	packetID, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	remaining := buf[len(buf)-r.Len():]
+	return packetID, remaining, nil
+}
+
+// writeVarInt writes value using the Minecraft protocol's VarInt encoding
+// (7 data bits per byte, MSB as a continuation flag).
+func writeVarInt(buf *bytes.Buffer, value int32) {
+	v := uint32(value)
+	for {
+		b := byte(v & 0x7F)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		buf.WriteByte(b)
+		if v == 0 {
+			return
+		}
+	}
+}
+
+// readVarInt reads a Minecraft protocol VarInt from r.
+func readVarInt(r io.Reader) (int32, error) {
+	var result uint32
+	var shift uint
+	b := make([]byte, 1)
+
+	for {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return 0, err
+		}
+		result |= uint32(b[0]&0x7F) << shift
+		if b[0]&0x80 == 0 {
+			break
+		}
+		shift += 7
+		if shift >= 35 {
+			return 0, fmt.Errorf("varint too long")
+		}
+	}
+
+	return int32(result), nil
+}
+
+// writeString writes a UTF-8 string with a VarInt length prefix.
+func writeString(buf *bytes.Buffer, s string) {
+	writeVarInt(buf, int32(len(s)))
+	buf.WriteString(s)
+}
+
+// readVarIntFromBytes decodes a VarInt from the start of b, returning the
+// value and the number of bytes it consumed.
+func readVarIntFromBytes(b []byte) (int32, int, error) {
+	r := bytes.NewReader(b)
+	v, err := readVarInt(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	return v, len(b) - r.Len(), nil
+}
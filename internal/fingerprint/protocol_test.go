@@ -0,0 +1,39 @@
+package fingerprint
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestReadPacketRejectsOversizedLength(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		var buf bytes.Buffer
+		writeVarInt(&buf, 2000000000) // declared length far beyond maxPacketLength
+		server.Write(buf.Bytes())
+	}()
+
+	if _, _, err := readPacket(client); err == nil {
+		t.Fatal("readPacket() with oversized length = nil error, want error")
+	}
+}
+
+func TestReadPacketRejectsNegativeLength(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go func() {
+		var buf bytes.Buffer
+		writeVarInt(&buf, -1) // VarInt encoding of a negative length
+		server.Write(buf.Bytes())
+	}()
+
+	if _, _, err := readPacket(client); err == nil {
+		t.Fatal("readPacket() with negative length = nil error, want error")
+	}
+}
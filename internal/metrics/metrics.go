@@ -0,0 +1,77 @@
+// Package metrics exposes the watcher's runtime state as Prometheus
+// metrics over HTTP so operators can alert on stuck idle timers or
+// reconnect storms without scraping logs.
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	PlayersOnline = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mcw_players_online",
+		Help: "Number of players currently online on the watched server.",
+	})
+
+	IdleSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mcw_idle_seconds",
+		Help: "Seconds since a player was last seen online.",
+	})
+
+	UptimeSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mcw_uptime_seconds",
+		Help: "Seconds since the watcher established its connection to the server.",
+	})
+
+	RPCRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mcw_rpc_requests_total",
+		Help: "Total JSON-RPC requests sent to the server, by method and outcome.",
+	}, []string{"method", "status"})
+
+	RPCLatencySeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mcw_rpc_latency_seconds",
+		Help:    "JSON-RPC round-trip latency, by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	ReconnectsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mcw_reconnects_total",
+		Help: "Total number of times the watcher has re-established its management connection after losing it.",
+	})
+
+	ShutdownTriggeredTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mcw_shutdown_triggered_total",
+		Help: "Total number of times the watcher has triggered a server shutdown.",
+	})
+
+	ServerInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mcw_server_info",
+		Help: "Always 1; labels describe the fingerprinted server software and version.",
+	}, []string{"software", "version"})
+)
+
+// Serve starts an HTTP server exposing /metrics on addr. It runs until ctx
+// is cancelled, at which point it is closed immediately, along with any
+// open connections.
+func Serve(ctx context.Context, addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	go func() {
+		_ = srv.ListenAndServe()
+	}()
+
+	return srv
+}
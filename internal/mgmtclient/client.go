@@ -0,0 +1,416 @@
+// Package mgmtclient is a reconnecting JSON-RPC client for a Minecraft
+// server's management websocket. It owns the connection lifecycle so
+// callers don't have to: requests are queued and survive reconnects, a
+// background heartbeat detects dead connections, and CallContext gives each
+// request its own timeout independent of the others in flight.
+package mgmtclient
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Request is a single JSON-RPC 2.0 request.
+type Request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	ID      int         `json:"id"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// Response is a single JSON-RPC 2.0 response.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC 2.0 error object, and also satisfies the error
+// interface so it can be returned directly from CallContext.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s (data: %s)", e.Code, e.Message, e.Data)
+}
+
+// NotificationHandler is invoked with the params of a server-pushed
+// notification (a message with no id) matching the method it was
+// registered for.
+type NotificationHandler func(params json.RawMessage)
+
+// envelope is used to peek at an inbound message before we know whether
+// it's a response (has an id) or a notification (has a method, no id).
+type envelope struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// Options configures a Client. Zero values fall back to sensible defaults
+// via setDefaults.
+type Options struct {
+	URL              string
+	Header           http.Header
+	TLSConfig        *tls.Config
+	HandshakeTimeout time.Duration
+	PingInterval     time.Duration
+	QueueSize        int
+	InitialBackoff   time.Duration
+	MaxBackoff       time.Duration
+	Logger           *zap.Logger
+}
+
+func (o *Options) setDefaults() {
+	if o.HandshakeTimeout == 0 {
+		o.HandshakeTimeout = 10 * time.Second
+	}
+	if o.PingInterval == 0 {
+		o.PingInterval = 30 * time.Second
+	}
+	if o.QueueSize == 0 {
+		o.QueueSize = 64
+	}
+	if o.InitialBackoff == 0 {
+		o.InitialBackoff = 1 * time.Second
+	}
+	if o.MaxBackoff == 0 {
+		o.MaxBackoff = 30 * time.Second
+	}
+	if o.Logger == nil {
+		o.Logger = zap.NewNop()
+	}
+}
+
+type call struct {
+	req    Request
+	respCh chan *Response
+
+	// cancelled is set once this call has been failed (by failCall,
+	// failPending, or a caller's ctx expiring) so that writeLoop can skip
+	// it instead of sending a request whose caller has already moved on.
+	cancelled int32
+}
+
+func (cl *call) markCancelled() bool {
+	return atomic.CompareAndSwapInt32(&cl.cancelled, 0, 1)
+}
+
+func (cl *call) isCancelled() bool {
+	return atomic.LoadInt32(&cl.cancelled) == 1
+}
+
+// Client is a reconnecting JSON-RPC client for the management websocket.
+// It's safe for concurrent use.
+type Client struct {
+	opts Options
+
+	connectedCh chan struct{}
+
+	pendingMu sync.Mutex
+	pending   map[int]*call
+
+	notifMu       sync.Mutex
+	notifHandlers map[string]NotificationHandler
+
+	queue chan *call
+
+	requestID int64
+}
+
+// New constructs a Client. Call Start to connect.
+func New(opts Options) *Client {
+	opts.setDefaults()
+	return &Client{
+		opts:          opts,
+		connectedCh:   make(chan struct{}, 1),
+		pending:       make(map[int]*call),
+		notifHandlers: make(map[string]NotificationHandler),
+		queue:         make(chan *call, opts.QueueSize),
+	}
+}
+
+// OnNotification registers h to run whenever a notification for method
+// arrives. Call this before Start so no notification can race registration.
+func (c *Client) OnNotification(method string, h NotificationHandler) {
+	c.notifMu.Lock()
+	c.notifHandlers[method] = h
+	c.notifMu.Unlock()
+}
+
+// Connected returns a channel that receives a value each time the client
+// establishes or re-establishes its connection. At most one signal is
+// buffered and sends are non-blocking, so a caller that doesn't read
+// promptly misses any signal sent while one is still pending.
+func (c *Client) Connected() <-chan struct{} {
+	return c.connectedCh
+}
+
+// Start dials the server and launches the background reconnect/heartbeat
+// loop, which keeps running until ctx is done. It blocks until the first
+// connection succeeds or ctx is done; failed dials in between are retried
+// with backoff rather than returned.
+func (c *Client) Start(ctx context.Context) error {
+	result := make(chan error, 1)
+	go c.manageLoop(ctx, result)
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// manageLoop owns the connection for its whole lifetime: dialing (with
+// exponential backoff on repeat failures), and restarting the read/write/
+// heartbeat goroutines on every reconnect.
+func (c *Client) manageLoop(ctx context.Context, first chan<- error) {
+	backoff := c.opts.InitialBackoff
+	reportedFirst := false
+
+	for {
+		if ctx.Err() != nil {
+			if !reportedFirst {
+				first <- ctx.Err()
+			}
+			return
+		}
+
+		dialer := websocket.Dialer{
+			HandshakeTimeout: c.opts.HandshakeTimeout,
+			TLSClientConfig:  c.opts.TLSConfig,
+		}
+		conn, _, err := dialer.DialContext(ctx, c.opts.URL, c.opts.Header)
+		if err != nil {
+			c.opts.Logger.Warn("management connection failed, retrying",
+				zap.Error(err), zap.Int64("backoff_ms", backoff.Milliseconds()))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				if !reportedFirst {
+					first <- ctx.Err()
+				}
+				return
+			}
+			backoff *= 2
+			if backoff > c.opts.MaxBackoff {
+				backoff = c.opts.MaxBackoff
+			}
+			continue
+		}
+
+		backoff = c.opts.InitialBackoff
+		c.opts.Logger.Info("management connection established")
+
+		if !reportedFirst {
+			first <- nil
+			reportedFirst = true
+		}
+		select {
+		case c.connectedCh <- struct{}{}:
+		default:
+		}
+
+		done := make(chan struct{})
+		var closeOnce sync.Once
+		closeDone := func() { closeOnce.Do(func() { close(done) }) }
+
+		go c.readLoop(conn, closeDone)
+		go c.heartbeatLoop(ctx, conn, closeDone)
+		go c.writeLoop(conn, closeDone, done)
+
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return
+		case <-done:
+			conn.Close()
+			c.failPending(fmt.Errorf("management connection lost"))
+		}
+	}
+}
+
+// readLoop owns all reads off conn for as long as it's the active
+// connection, demultiplexing responses to pending callers and notifications
+// to registered handlers.
+func (c *Client) readLoop(conn *websocket.Conn, closeDone func()) {
+	defer closeDone()
+
+	for {
+		var env envelope
+		if err := conn.ReadJSON(&env); err != nil {
+			c.opts.Logger.Warn("management connection read failed", zap.Error(err))
+			return
+		}
+
+		switch {
+		case env.ID != nil:
+			c.dispatchResponse(env)
+		case env.Method != "":
+			c.dispatchNotification(env)
+		}
+	}
+}
+
+func (c *Client) dispatchResponse(env envelope) {
+	c.pendingMu.Lock()
+	cl, ok := c.pending[*env.ID]
+	if ok {
+		delete(c.pending, *env.ID)
+	}
+	c.pendingMu.Unlock()
+
+	if !ok {
+		c.opts.Logger.Warn("no pending request for response id", zap.Int("rpc_id", *env.ID))
+		return
+	}
+	cl.respCh <- &Response{JSONRPC: env.JSONRPC, ID: *env.ID, Result: env.Result, Error: env.Error}
+}
+
+func (c *Client) dispatchNotification(env envelope) {
+	c.notifMu.Lock()
+	h, ok := c.notifHandlers[env.Method]
+	c.notifMu.Unlock()
+
+	if !ok {
+		c.opts.Logger.Debug("no handler registered for notification", zap.String("method", env.Method))
+		return
+	}
+	h(env.Params)
+}
+
+// heartbeatLoop sends a websocket ping on PingInterval and tears down the
+// connection (via closeDone) if a ping can't be written, catching dead
+// connections that wouldn't otherwise produce a read or write error.
+func (c *Client) heartbeatLoop(ctx context.Context, conn *websocket.Conn, closeDone func()) {
+	ticker := time.NewTicker(c.opts.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				c.opts.Logger.Warn("heartbeat ping failed", zap.Error(err))
+				closeDone()
+				return
+			}
+		}
+	}
+}
+
+// writeLoop drains the outgoing queue onto conn for as long as it's the
+// active connection. Because the queue isn't reset on reconnect, calls
+// queued while disconnected are simply sent once a new writeLoop starts --
+// unless they were already cancelled (failed out from under a dropped
+// connection or an expired ctx), in which case the caller has already moved
+// on and the request must not be sent a second time.
+func (c *Client) writeLoop(conn *websocket.Conn, closeDone func(), done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			return
+		case cl := <-c.queue:
+			if cl.isCancelled() {
+				continue
+			}
+			if err := conn.WriteJSON(cl.req); err != nil {
+				c.failCall(cl, fmt.Errorf("failed to send request: %w", err))
+				closeDone()
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) failCall(cl *call, err error) {
+	if !cl.markCancelled() {
+		return
+	}
+
+	c.pendingMu.Lock()
+	delete(c.pending, cl.req.ID)
+	c.pendingMu.Unlock()
+
+	select {
+	case cl.respCh <- &Response{Error: &RPCError{Message: err.Error()}}:
+	default:
+	}
+}
+
+func (c *Client) failPending(err error) {
+	c.pendingMu.Lock()
+	pending := c.pending
+	c.pending = make(map[int]*call)
+	c.pendingMu.Unlock()
+
+	for _, cl := range pending {
+		cl.markCancelled()
+		select {
+		case cl.respCh <- &Response{Error: &RPCError{Message: err.Error()}}:
+		default:
+		}
+	}
+}
+
+// CallContext sends method/params and decodes the response into result,
+// which may be nil if the caller doesn't need one. The call is queued
+// immediately (surviving any reconnect in progress) and bounded by ctx.
+func (c *Client) CallContext(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := int(atomic.AddInt64(&c.requestID, 1))
+	req := Request{JSONRPC: "2.0", Method: method, ID: id, Params: params}
+	cl := &call{req: req, respCh: make(chan *Response, 1)}
+
+	c.pendingMu.Lock()
+	c.pending[id] = cl
+	c.pendingMu.Unlock()
+
+	select {
+	case c.queue <- cl:
+	case <-ctx.Done():
+		cl.markCancelled()
+		c.pendingMu.Lock()
+		delete(c.pending, id)
+		c.pendingMu.Unlock()
+		return ctx.Err()
+	}
+
+	select {
+	case resp := <-cl.respCh:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("failed to decode result: %w", err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		// cl may already be sitting unsent in the queue; marking it
+		// cancelled stops writeLoop from sending it after we've given up.
+		cl.markCancelled()
+		c.pendingMu.Lock()
+		delete(c.pending, id)
+		c.pendingMu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,118 @@
+package mgmtclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestServer starts a websocket server that echoes back each request as
+// a {"result": <method>} response, and once it sees a request for
+// "subscribe", pushes a single "notification:test" notification.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var req Request
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+
+			resp := Response{JSONRPC: "2.0", ID: req.ID, Result: []byte(`"` + req.Method + `"`)}
+			if err := conn.WriteJSON(resp); err != nil {
+				return
+			}
+
+			if req.Method == "subscribe" {
+				notif := map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "notification:test",
+					"params":  map[string]string{"hello": "world"},
+				}
+				if err := conn.WriteJSON(notif); err != nil {
+					return
+				}
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func wsURL(httpURL string) string {
+	return "ws" + strings.TrimPrefix(httpURL, "http")
+}
+
+func TestCallContextRoundTrip(t *testing.T) {
+	srv := newTestServer(t)
+
+	c := New(Options{URL: wsURL(srv.URL)})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	var result string
+	if err := c.CallContext(ctx, "minecraft:players", nil, &result); err != nil {
+		t.Fatalf("CallContext: %v", err)
+	}
+	if result != "minecraft:players" {
+		t.Errorf("result = %q, want %q", result, "minecraft:players")
+	}
+}
+
+func TestNotificationDispatch(t *testing.T) {
+	srv := newTestServer(t)
+
+	c := New(Options{URL: wsURL(srv.URL)})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan string, 1)
+	c.OnNotification("notification:test", func(params json.RawMessage) {
+		received <- string(params)
+	})
+
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	if err := c.CallContext(ctx, "subscribe", nil, nil); err != nil {
+		t.Fatalf("CallContext: %v", err)
+	}
+
+	select {
+	case params := <-received:
+		if !strings.Contains(params, "world") {
+			t.Errorf("notification params = %q, want to contain %q", params, "world")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+}
+
+func TestStartTimesOutWhenUnreachable(t *testing.T) {
+	c := New(Options{URL: "ws://127.0.0.1:1"})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := c.Start(ctx); err == nil {
+		t.Fatal("Start: expected error dialing an unreachable address")
+	}
+}